@@ -0,0 +1,217 @@
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func init() {
+	RegisterFactory(adminStatsFactory{})
+}
+
+type adminStatsFactory struct{}
+
+func (adminStatsFactory) Name() string { return "admin_stats" }
+
+func (adminStatsFactory) New(ctx context.Context, deps Dependencies, reg prometheus.Registerer) error {
+	if deps.BaseURL == "" {
+		return errors.New("admin_stats collector requires GITHUB_BASE_URL to be set")
+	}
+
+	httpClient, err := deps.NewHTTPClient("admin_stats")
+	if err != nil {
+		return err
+	}
+
+	reg.MustRegister(NewAdminStatsCollector(deps.Logger, httpClient, deps.BaseURL))
+	return nil
+}
+
+// AdminStatsCollector collects metrics from the GitHub Enterprise Server
+// admin stats REST endpoint. This data isn't available through the GraphQL
+// API, so it talks to the REST API directly.
+type AdminStatsCollector struct {
+	logger     log.Logger
+	httpClient *http.Client
+	baseURL    string
+
+	repos      *prometheus.Desc
+	hooks      *prometheus.Desc
+	pages      *prometheus.Desc
+	orgs       *prometheus.Desc
+	users      *prometheus.Desc
+	pulls      *prometheus.Desc
+	issues     *prometheus.Desc
+	milestones *prometheus.Desc
+	comments   *prometheus.Desc
+	gists      *prometheus.Desc
+}
+
+// adminStats mirrors the subset of fields returned by the
+// /enterprise/stats/all REST endpoint that we expose as metrics.
+type adminStats struct {
+	Repos struct {
+		TotalRepos int64 `json:"total_repos"`
+	} `json:"repos"`
+	Hooks struct {
+		TotalHooks int64 `json:"total_hooks"`
+	} `json:"hooks"`
+	Pages struct {
+		TotalPages int64 `json:"total_pages"`
+	} `json:"pages"`
+	Orgs struct {
+		TotalOrgs int64 `json:"total_orgs"`
+	} `json:"orgs"`
+	Users struct {
+		TotalUsers int64 `json:"total_users"`
+	} `json:"users"`
+	Pulls struct {
+		TotalPulls int64 `json:"total_pulls"`
+	} `json:"pulls"`
+	Issues struct {
+		TotalIssues int64 `json:"total_issues"`
+	} `json:"issues"`
+	Milestones struct {
+		TotalMilestones int64 `json:"total_milestones"`
+	} `json:"milestones"`
+	Comments struct {
+		TotalComments int64 `json:"total_comments"`
+	} `json:"comments"`
+	Gists struct {
+		TotalGists int64 `json:"total_gists"`
+	} `json:"gists"`
+}
+
+// NewAdminStatsCollector returns a new AdminStatsCollector. baseURL is the
+// GitHub Enterprise Server GraphQL endpoint as configured via
+// GITHUB_BASE_URL (e.g. https://github.example.com/api/graphql); the REST
+// v3 API base it actually talks to is derived from it.
+func NewAdminStatsCollector(logger log.Logger, httpClient *http.Client, baseURL string) *AdminStatsCollector {
+	return &AdminStatsCollector{
+		logger:     logger,
+		httpClient: httpClient,
+		baseURL:    RESTBaseURL(baseURL),
+
+		repos: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "enterprise", "repos"),
+			"Total number of repositories on the enterprise instance",
+			nil, nil,
+		),
+		hooks: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "enterprise", "hooks"),
+			"Total number of hooks on the enterprise instance",
+			nil, nil,
+		),
+		pages: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "enterprise", "pages"),
+			"Total number of GitHub Pages sites on the enterprise instance",
+			nil, nil,
+		),
+		orgs: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "enterprise", "orgs"),
+			"Total number of organizations on the enterprise instance",
+			nil, nil,
+		),
+		users: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "enterprise", "users"),
+			"Total number of users on the enterprise instance",
+			nil, nil,
+		),
+		pulls: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "enterprise", "pulls"),
+			"Total number of pull requests on the enterprise instance",
+			nil, nil,
+		),
+		issues: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "enterprise", "issues"),
+			"Total number of issues on the enterprise instance",
+			nil, nil,
+		),
+		milestones: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "enterprise", "milestones"),
+			"Total number of milestones on the enterprise instance",
+			nil, nil,
+		),
+		comments: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "enterprise", "comments"),
+			"Total number of comments on the enterprise instance",
+			nil, nil,
+		),
+		gists: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "enterprise", "gists"),
+			"Total number of gists on the enterprise instance",
+			nil, nil,
+		),
+	}
+}
+
+// Describe sends the super-set of all possible descriptors of metrics
+// collected by this Collector.
+func (c *AdminStatsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.repos
+	ch <- c.hooks
+	ch <- c.pages
+	ch <- c.orgs
+	ch <- c.users
+	ch <- c.pulls
+	ch <- c.issues
+	ch <- c.milestones
+	ch <- c.comments
+	ch <- c.gists
+}
+
+// Collect is called by the Prometheus registry when collecting metrics.
+func (c *AdminStatsCollector) Collect(ch chan<- prometheus.Metric) {
+	stats, err := c.fetchStats()
+	if err != nil {
+		level.Warn(c.logger).Log("msg", "failed to fetch enterprise admin stats", "err", err)
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.repos, prometheus.GaugeValue, float64(stats.Repos.TotalRepos))
+	ch <- prometheus.MustNewConstMetric(c.hooks, prometheus.GaugeValue, float64(stats.Hooks.TotalHooks))
+	ch <- prometheus.MustNewConstMetric(c.pages, prometheus.GaugeValue, float64(stats.Pages.TotalPages))
+	ch <- prometheus.MustNewConstMetric(c.orgs, prometheus.GaugeValue, float64(stats.Orgs.TotalOrgs))
+	ch <- prometheus.MustNewConstMetric(c.users, prometheus.GaugeValue, float64(stats.Users.TotalUsers))
+	ch <- prometheus.MustNewConstMetric(c.pulls, prometheus.GaugeValue, float64(stats.Pulls.TotalPulls))
+	ch <- prometheus.MustNewConstMetric(c.issues, prometheus.GaugeValue, float64(stats.Issues.TotalIssues))
+	ch <- prometheus.MustNewConstMetric(c.milestones, prometheus.GaugeValue, float64(stats.Milestones.TotalMilestones))
+	ch <- prometheus.MustNewConstMetric(c.comments, prometheus.GaugeValue, float64(stats.Comments.TotalComments))
+	ch <- prometheus.MustNewConstMetric(c.gists, prometheus.GaugeValue, float64(stats.Gists.TotalGists))
+}
+
+func (c *AdminStatsCollector) fetchStats() (*adminStats, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequest(http.MethodGet, c.baseURL+"/enterprise/stats/all", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %v", err)
+	}
+	req = req.WithContext(ctx)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	var stats adminStats
+	if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %v", err)
+	}
+
+	return &stats, nil
+}
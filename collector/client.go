@@ -0,0 +1,29 @@
+package collector
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/shurcooL/githubql"
+)
+
+// NewGithubqlClient returns a githubql.Client for the public GitHub GraphQL
+// API, or for a GitHub Enterprise Server instance when baseURL is set.
+func NewGithubqlClient(httpClient *http.Client, baseURL string) *githubql.Client {
+	if baseURL != "" {
+		return githubql.NewEnterpriseClient(baseURL, httpClient)
+	}
+	return githubql.NewClient(httpClient)
+}
+
+// RESTBaseURL derives the REST v3 API base (e.g.
+// "https://github.example.com/api/v3") from graphqlBaseURL, the GraphQL
+// endpoint configured via GITHUB_BASE_URL (e.g.
+// "https://github.example.com/api/graphql"). GitHub Enterprise Server
+// exposes both APIs under the same host, so the two can't share one value
+// verbatim.
+func RESTBaseURL(graphqlBaseURL string) string {
+	base := strings.TrimRight(graphqlBaseURL, "/")
+	base = strings.TrimSuffix(base, "/api/graphql")
+	return base + "/api/v3"
+}
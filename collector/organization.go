@@ -2,21 +2,29 @@ package collector
 
 import (
 	"context"
-	"time"
 
 	"github.com/go-kit/kit/log"
-	"github.com/go-kit/kit/log/level"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/shurcooL/githubql"
 )
 
 const namespace = "github"
 
-// OrganizationCollector collects metrics about the account.
+// RepoCache is anything that keeps a background-refreshed set of repo
+// metrics, such as a Runner, a RepositoriesRunner or a UserRunner.
+// OrganizationCollector reads from one; the "organization", "repositories"
+// and "user" collectors each back it with their own runner.
+type RepoCache interface {
+	Snapshot() []*RepoMetrics
+}
+
+// OrganizationCollector collects metrics about the account. The actual
+// GraphQL querying happens in the background runner behind its RepoCache;
+// Collect only walks that cache, so a Prometheus scrape is never slower than
+// reading memory.
 type OrganizationCollector struct {
-	logger        log.Logger
-	client        *githubql.Client
-	organizations []string
+	logger log.Logger
+	cache  RepoCache
 
 	created      *prometheus.Desc
 	diskUsage    *prometheus.Desc
@@ -26,121 +34,88 @@ type OrganizationCollector struct {
 	pushed       *prometheus.Desc
 	stargazers   *prometheus.Desc
 	watchers     *prometheus.Desc
+}
 
-	rateLimit          *prometheus.Desc
-	rateLimitRemaining *prometheus.Desc
-	rateLimitReset     *prometheus.Desc
+type rateLimit struct {
+	Limit     githubql.Int
+	Remaining githubql.Int
+	ResetAt   githubql.DateTime
 }
 
-type (
-	organizationQuery struct {
-		Organization struct {
-			Login        githubql.String
-			Repositories struct {
-				Nodes []struct {
-					Name      githubql.String
-					DiskUsage githubql.Int
-					CreatedAt githubql.DateTime
-					PushedAt  githubql.DateTime
+func init() {
+	RegisterFactory(organizationFactory{})
+}
 
-					Stargazers struct {
-						TotalCount githubql.Int
-					}
-					Watchers struct {
-						TotalCount githubql.Int
-					}
-					Forks struct {
-						TotalCount githubql.Int
-					}
-					IssuesOpen struct {
-						TotalCount githubql.Int
-					} `graphql:"issuesOpen: issues(states: OPEN)"`
-					IssuesClosed struct {
-						TotalCount githubql.Int
-					} `graphql:"issuesClosed: issues(states: CLOSED)"`
-					PullRequestsOpen struct {
-						TotalCount githubql.Int
-					} `graphql:"PullRequestsOpen: pullRequests(states: OPEN)"`
-					PullRequestsClosed struct {
-						TotalCount githubql.Int
-					} `graphql:"PullRequestsClosed: pullRequests(states: CLOSED)"`
-					PullRequestsMerged struct {
-						TotalCount githubql.Int
-					} `graphql:"PullRequestsMerged: pullRequests(states: MERGED)"`
-				}
-			} `graphql:"repositories(first: 100)"`
-		} `graphql:"organization(login: $organization)"`
-		RateLimit rateLimit
-	}
-	rateLimit struct {
-		Limit     githubql.Int
-		Remaining githubql.Int
-		ResetAt   githubql.DateTime
+type organizationFactory struct{}
+
+func (organizationFactory) Name() string { return "organization" }
+
+func (organizationFactory) New(ctx context.Context, deps Dependencies, reg prometheus.Registerer) error {
+	httpClient, err := deps.NewHTTPClient("organization")
+	if err != nil {
+		return err
 	}
-)
+	client := NewGithubqlClient(httpClient, deps.BaseURL)
+
+	runner := NewRunner(deps.Logger, client, deps.Organizations, deps.ScrapeInterval, deps.RateLimitThreshold, deps.AuthType)
+	go runner.Run(ctx)
+
+	reg.MustRegister(runner)
+	reg.MustRegister(NewOrganizationCollector(deps.Logger, runner, "organization"))
+	return nil
+}
+
+// NewOrganizationCollector returns a new OrganizationCollector backed by the
+// given RepoCache. source identifies which --collectors entry this instance
+// backs (e.g. "organization", "repositories", "user") and is baked in as a
+// const label, so registering more than one of these side by side doesn't
+// collide on identical descriptors.
+func NewOrganizationCollector(logger log.Logger, cache RepoCache, source string) *OrganizationCollector {
+	constLabels := prometheus.Labels{"source": source}
 
-// NewOrganizationCollector returns a new OrganizationCollector.
-func NewOrganizationCollector(logger log.Logger, client *githubql.Client, organizations []string) *OrganizationCollector {
 	return &OrganizationCollector{
-		logger:        logger,
-		client:        client,
-		organizations: organizations,
+		logger: logger,
+		cache:  cache,
 
 		created: prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, "repo", "created"),
 			"Unix timestamp of when the repo was created",
-			[]string{"owner", "name"}, nil,
+			[]string{"owner", "name"}, constLabels,
 		),
 		diskUsage: prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, "repo", "disk_usage_bytes"),
 			"Bytes of the repository used on disk",
-			[]string{"owner", "name"}, nil,
+			[]string{"owner", "name"}, constLabels,
 		),
 		forks: prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, "repo", "forks"),
 			"Number of forks of that repo",
-			[]string{"owner", "name"}, nil,
+			[]string{"owner", "name"}, constLabels,
 		),
 		issues: prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, "repo", "issues"),
 			"Number of issues with a state of open or closed",
-			[]string{"owner", "name", "state"}, nil,
+			[]string{"owner", "name", "state"}, constLabels,
 		),
 		pullRequests: prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, "repo", "pull_requests"),
 			"Number of pull requests with a state of open, closed or merged",
-			[]string{"owner", "name", "state"}, nil,
+			[]string{"owner", "name", "state"}, constLabels,
 		),
 		pushed: prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, "repo", "pushed"),
 			"Unix timestamp of then the repo was pushed to the last time",
-			[]string{"onwer", "name"}, nil,
+			[]string{"onwer", "name"}, constLabels,
 		),
 		stargazers: prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, "repo", "stargazers"),
 			"Number of users that star the repo",
-			[]string{"onwer", "name"}, nil,
+			[]string{"onwer", "name"}, constLabels,
 		),
 		watchers: prometheus.NewDesc(
 			prometheus.BuildFQName(namespace, "repo", "watchers"),
 			"Number of users that watch the repo",
-			[]string{"onwer", "name"}, nil,
-		),
-
-		rateLimit: prometheus.NewDesc(
-			prometheus.BuildFQName(namespace, "rate_limit", "limit"),
-			"The rate limit",
-			nil, nil,
-		),
-		rateLimitRemaining: prometheus.NewDesc(
-			prometheus.BuildFQName(namespace, "rate_limit", "remaining"),
-			"The remaining requests left until hitting the rate limit",
-			nil, nil,
-		),
-		rateLimitReset: prometheus.NewDesc(
-			prometheus.BuildFQName(namespace, "rate_limit", "reset_seconds"),
-			"Unix timestamp when the rate limit will be reset",
-			nil, nil,
+			[]string{"onwer", "name"}, constLabels,
 		),
 	}
 }
@@ -156,115 +131,76 @@ func (c *OrganizationCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- c.pushed
 	ch <- c.stargazers
 	ch <- c.watchers
-
-	ch <- c.rateLimit
-	ch <- c.rateLimitRemaining
-	ch <- c.rateLimitReset
 }
 
 // Collect is called by the Prometheus registry when collecting metrics.
 func (c *OrganizationCollector) Collect(ch chan<- prometheus.Metric) {
-	var rateLimit rateLimit
-
-	for _, organization := range c.organizations {
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-
-		variables := map[string]interface{}{
-			"organization": githubql.String(organization),
-		}
-
-		var query organizationQuery
-		if err := c.client.Query(ctx, &query, variables); err != nil {
-			level.Warn(c.logger).Log("msg", "failed to execute organization query successfully", "err", err)
-			return
-		}
-
-		rateLimit = query.RateLimit
-
-		for _, repo := range query.Organization.Repositories.Nodes {
-			ch <- prometheus.MustNewConstMetric(
-				c.created,
-				prometheus.GaugeValue,
-				float64(repo.CreatedAt.Unix()),
-				string(query.Organization.Login), string(repo.Name),
-			)
-			ch <- prometheus.MustNewConstMetric(
-				c.diskUsage,
-				prometheus.GaugeValue,
-				float64(repo.DiskUsage),
-				string(query.Organization.Login), string(repo.Name),
-			)
-			ch <- prometheus.MustNewConstMetric(
-				c.forks,
-				prometheus.GaugeValue,
-				float64(repo.Forks.TotalCount),
-				string(query.Organization.Login), string(repo.Name),
-			)
-			ch <- prometheus.MustNewConstMetric(
-				c.issues,
-				prometheus.GaugeValue,
-				float64(repo.IssuesOpen.TotalCount),
-				string(query.Organization.Login), string(repo.Name), "open",
-			)
-			ch <- prometheus.MustNewConstMetric(
-				c.issues,
-				prometheus.GaugeValue,
-				float64(repo.IssuesClosed.TotalCount),
-				string(query.Organization.Login), string(repo.Name), "closed",
-			)
-			ch <- prometheus.MustNewConstMetric(
-				c.pullRequests,
-				prometheus.GaugeValue,
-				float64(repo.PullRequestsOpen.TotalCount),
-				string(query.Organization.Login), string(repo.Name), "open",
-			)
-			ch <- prometheus.MustNewConstMetric(
-				c.pullRequests,
-				prometheus.GaugeValue,
-				float64(repo.PullRequestsClosed.TotalCount),
-				string(query.Organization.Login), string(repo.Name), "closed",
-			)
-			ch <- prometheus.MustNewConstMetric(
-				c.pullRequests,
-				prometheus.GaugeValue,
-				float64(repo.PullRequestsMerged.TotalCount),
-				string(query.Organization.Login), string(repo.Name), "merged",
-			)
-			ch <- prometheus.MustNewConstMetric(
-				c.pushed,
-				prometheus.GaugeValue,
-				float64(repo.PushedAt.Unix()),
-				string(query.Organization.Login), string(repo.Name),
-			)
-			ch <- prometheus.MustNewConstMetric(
-				c.stargazers,
-				prometheus.GaugeValue,
-				float64(repo.Stargazers.TotalCount),
-				string(query.Organization.Login), string(repo.Name),
-			)
-			ch <- prometheus.MustNewConstMetric(
-				c.watchers,
-				prometheus.GaugeValue,
-				float64(repo.Watchers.TotalCount),
-				string(query.Organization.Login), string(repo.Name),
-			)
-		}
+	for _, repo := range c.cache.Snapshot() {
+		ch <- prometheus.MustNewConstMetric(
+			c.created,
+			prometheus.GaugeValue,
+			float64(repo.CreatedAt.Unix()),
+			repo.Owner, repo.Name,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			c.diskUsage,
+			prometheus.GaugeValue,
+			float64(repo.DiskUsage),
+			repo.Owner, repo.Name,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			c.forks,
+			prometheus.GaugeValue,
+			float64(repo.Forks),
+			repo.Owner, repo.Name,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			c.issues,
+			prometheus.GaugeValue,
+			float64(repo.IssuesOpen),
+			repo.Owner, repo.Name, "open",
+		)
+		ch <- prometheus.MustNewConstMetric(
+			c.issues,
+			prometheus.GaugeValue,
+			float64(repo.IssuesClosed),
+			repo.Owner, repo.Name, "closed",
+		)
+		ch <- prometheus.MustNewConstMetric(
+			c.pullRequests,
+			prometheus.GaugeValue,
+			float64(repo.PullRequestsOpen),
+			repo.Owner, repo.Name, "open",
+		)
+		ch <- prometheus.MustNewConstMetric(
+			c.pullRequests,
+			prometheus.GaugeValue,
+			float64(repo.PullRequestsClosed),
+			repo.Owner, repo.Name, "closed",
+		)
+		ch <- prometheus.MustNewConstMetric(
+			c.pullRequests,
+			prometheus.GaugeValue,
+			float64(repo.PullRequestsMerged),
+			repo.Owner, repo.Name, "merged",
+		)
+		ch <- prometheus.MustNewConstMetric(
+			c.pushed,
+			prometheus.GaugeValue,
+			float64(repo.PushedAt.Unix()),
+			repo.Owner, repo.Name,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			c.stargazers,
+			prometheus.GaugeValue,
+			float64(repo.Stargazers),
+			repo.Owner, repo.Name,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			c.watchers,
+			prometheus.GaugeValue,
+			float64(repo.Watchers),
+			repo.Owner, repo.Name,
+		)
 	}
-
-	ch <- prometheus.MustNewConstMetric(
-		c.rateLimit,
-		prometheus.GaugeValue,
-		float64(rateLimit.Limit),
-	)
-	ch <- prometheus.MustNewConstMetric(
-		c.rateLimitRemaining,
-		prometheus.GaugeValue,
-		float64(rateLimit.Remaining),
-	)
-	ch <- prometheus.MustNewConstMetric(
-		c.rateLimitReset,
-		prometheus.GaugeValue,
-		float64(rateLimit.ResetAt.Unix()),
-	)
 }
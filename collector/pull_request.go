@@ -0,0 +1,335 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/shurcooL/githubql"
+)
+
+// pullRequestAgeBuckets mirror the thresholds operators care about when
+// alerting on stale pull requests: an hour, six hours, a day, three days,
+// a week, and thirty days.
+var pullRequestAgeBuckets = []float64{
+	time.Hour.Seconds(),
+	6 * time.Hour.Seconds(),
+	24 * time.Hour.Seconds(),
+	3 * 24 * time.Hour.Seconds(),
+	7 * 24 * time.Hour.Seconds(),
+	30 * 24 * time.Hour.Seconds(),
+}
+
+// PullRequestMetrics holds the metrics of a single open pull request as
+// collected by the PullRequestRunner.
+type PullRequestMetrics struct {
+	Owner          string
+	Name           string
+	Number         int
+	Author         string
+	ReviewDecision string
+	CIState        string
+	Draft          bool
+	CreatedAt      time.Time
+}
+
+// PullRequestRunner periodically pages through the open pull requests of
+// every repository known to the given RepoCache. It is opt-in since paging
+// every repo's pull requests burns substantially more of the GraphQL
+// rate-limit budget than the repository-level aggregates.
+type PullRequestRunner struct {
+	logger   log.Logger
+	client   *githubql.Client
+	repos    RepoCache
+	interval time.Duration
+
+	mu    sync.RWMutex
+	cache map[string][]*PullRequestMetrics
+}
+
+type (
+	repositoryPullRequestsQuery struct {
+		Repository struct {
+			PullRequests struct {
+				Nodes []struct {
+					Number         githubql.Int
+					CreatedAt      githubql.DateTime
+					IsDraft        githubql.Boolean
+					ReviewDecision githubql.String
+					Author         struct {
+						Login githubql.String
+					}
+					Commits struct {
+						Nodes []struct {
+							Commit struct {
+								StatusCheckRollup struct {
+									State githubql.String
+								}
+							}
+						}
+					} `graphql:"commits(last: 1)"`
+				}
+				PageInfo struct {
+					EndCursor   githubql.String
+					HasNextPage githubql.Boolean
+				}
+			} `graphql:"pullRequests(states: OPEN, first: 100, after: $cursor)"`
+		} `graphql:"repository(owner: $owner, name: $name)"`
+		RateLimit rateLimit
+	}
+)
+
+// NewPullRequestRunner returns a new PullRequestRunner. It discovers which
+// repositories to page through from repos' cache on every cycle.
+func NewPullRequestRunner(logger log.Logger, client *githubql.Client, repos RepoCache, interval time.Duration) *PullRequestRunner {
+	return &PullRequestRunner{
+		logger:   logger,
+		client:   client,
+		repos:    repos,
+		interval: interval,
+		cache:    make(map[string][]*PullRequestMetrics),
+	}
+}
+
+// Run pages through every known repository's open pull requests on
+// r.interval until ctx is canceled.
+func (r *PullRequestRunner) Run(ctx context.Context) {
+	r.scrape(ctx)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.scrape(ctx)
+		}
+	}
+}
+
+func (r *PullRequestRunner) scrape(ctx context.Context) {
+	for _, repo := range r.repos.Snapshot() {
+		metrics, err := r.scrapeRepository(ctx, repo.Owner, repo.Name)
+		if err != nil {
+			level.Warn(r.logger).Log("msg", "failed to scrape pull requests", "owner", repo.Owner, "name", repo.Name, "err", err)
+			continue
+		}
+
+		r.mu.Lock()
+		r.cache[repo.Owner+"/"+repo.Name] = metrics
+		r.mu.Unlock()
+	}
+}
+
+func (r *PullRequestRunner) scrapeRepository(ctx context.Context, owner, name string) ([]*PullRequestMetrics, error) {
+	var metrics []*PullRequestMetrics
+
+	var cursor githubql.String
+	hasCursor := false
+
+	for {
+		variables := map[string]interface{}{
+			"owner":  githubql.String(owner),
+			"name":   githubql.String(name),
+			"cursor": (*githubql.String)(nil),
+		}
+		if hasCursor {
+			variables["cursor"] = &cursor
+		}
+
+		queryCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		var query repositoryPullRequestsQuery
+		err := r.client.Query(queryCtx, &query, variables)
+		cancel()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, pr := range query.Repository.PullRequests.Nodes {
+			ciState := "UNKNOWN"
+			if len(pr.Commits.Nodes) > 0 {
+				if state := string(pr.Commits.Nodes[0].Commit.StatusCheckRollup.State); state != "" {
+					ciState = state
+				}
+			}
+
+			reviewDecision := string(pr.ReviewDecision)
+			if reviewDecision == "" {
+				reviewDecision = "NONE"
+			}
+
+			metrics = append(metrics, &PullRequestMetrics{
+				Owner:          owner,
+				Name:           name,
+				Number:         int(pr.Number),
+				Author:         string(pr.Author.Login),
+				ReviewDecision: reviewDecision,
+				CIState:        ciState,
+				Draft:          bool(pr.IsDraft),
+				CreatedAt:      pr.CreatedAt.Time,
+			})
+		}
+
+		if !bool(query.Repository.PullRequests.PageInfo.HasNextPage) {
+			break
+		}
+		cursor = query.Repository.PullRequests.PageInfo.EndCursor
+		hasCursor = true
+	}
+
+	return metrics, nil
+}
+
+// Snapshot returns a copy of all open pull request metrics currently in the
+// cache.
+func (r *PullRequestRunner) Snapshot() []*PullRequestMetrics {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var metrics []*PullRequestMetrics
+	for _, repoMetrics := range r.cache {
+		metrics = append(metrics, repoMetrics...)
+	}
+	return metrics
+}
+
+// PullRequestCollector exposes per-pull-request age and state metrics
+// backed by a PullRequestRunner. It must be opted into explicitly since it
+// burns substantially more of the GraphQL rate-limit budget than
+// OrganizationCollector.
+type PullRequestCollector struct {
+	logger log.Logger
+	runner *PullRequestRunner
+
+	age  *prometheus.Desc
+	open *prometheus.Desc
+}
+
+// NewPullRequestCollector returns a new PullRequestCollector.
+func NewPullRequestCollector(logger log.Logger, runner *PullRequestRunner) *PullRequestCollector {
+	return &PullRequestCollector{
+		logger: logger,
+		runner: runner,
+
+		age: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "pull_request", "age_seconds"),
+			"Age of open pull requests in seconds",
+			[]string{"owner", "repo"}, nil,
+		),
+		open: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "pull_request", "open"),
+			"Age in seconds of an open pull request, labeled by its number, author, review decision, CI state and draft status",
+			[]string{"owner", "repo", "number", "author", "review_decision", "ci_state", "draft"}, nil,
+		),
+	}
+}
+
+// Describe sends the super-set of all possible descriptors of metrics
+// collected by this Collector.
+func (c *PullRequestCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.age
+	ch <- c.open
+}
+
+type repoKey struct {
+	owner string
+	name  string
+}
+
+// Collect is called by the Prometheus registry when collecting metrics.
+func (c *PullRequestCollector) Collect(ch chan<- prometheus.Metric) {
+	ages := make(map[repoKey][]float64)
+
+	for _, pr := range c.runner.Snapshot() {
+		age := time.Since(pr.CreatedAt).Seconds()
+
+		ch <- prometheus.MustNewConstMetric(
+			c.open,
+			prometheus.GaugeValue,
+			age,
+			pr.Owner, pr.Name, strconv.Itoa(pr.Number), pr.Author, pr.ReviewDecision, pr.CIState, strconv.FormatBool(pr.Draft),
+		)
+
+		key := repoKey{owner: pr.Owner, name: pr.Name}
+		ages[key] = append(ages[key], age)
+	}
+
+	for key, repoAges := range ages {
+		ch <- prometheus.MustNewConstHistogram(
+			c.age,
+			uint64(len(repoAges)), sum(repoAges), bucketCounts(repoAges, pullRequestAgeBuckets),
+			key.owner, key.name,
+		)
+	}
+}
+
+func sum(values []float64) float64 {
+	var total float64
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
+
+// bucketCounts turns observations into the cumulative per-bucket counts
+// MustNewConstHistogram expects.
+func bucketCounts(values []float64, buckets []float64) map[float64]uint64 {
+	counts := make(map[float64]uint64, len(buckets))
+	for _, bucket := range buckets {
+		var count uint64
+		for _, v := range values {
+			if v <= bucket {
+				count++
+			}
+		}
+		counts[bucket] = count
+	}
+	return counts
+}
+
+func init() {
+	RegisterFactory(pullRequestsFactory{})
+}
+
+type pullRequestsFactory struct{}
+
+func (pullRequestsFactory) Name() string { return "pull_requests" }
+
+func (pullRequestsFactory) New(ctx context.Context, deps Dependencies, reg prometheus.Registerer) error {
+	httpClient, err := deps.NewHTTPClient("pull_requests")
+	if err != nil {
+		return err
+	}
+	client := NewGithubqlClient(httpClient, deps.BaseURL)
+
+	var repos RepoCache
+	switch {
+	case len(deps.Organizations) > 0:
+		discovery := NewRunner(deps.Logger, client, deps.Organizations, deps.ScrapeInterval, deps.RateLimitThreshold, deps.AuthType)
+		go discovery.Run(ctx)
+		repos = discovery
+	case len(deps.Repos) > 0:
+		discovery := NewRepositoriesRunner(deps.Logger, client, deps.Repos, deps.ScrapeInterval)
+		go discovery.Run(ctx)
+		repos = discovery
+	case len(deps.Users) > 0:
+		discovery := NewUserRunner(deps.Logger, client, deps.Users, deps.ScrapeInterval)
+		go discovery.Run(ctx)
+		repos = discovery
+	default:
+		return errors.New("pull_requests collector requires ORGS, REPOS or USERS to be set")
+	}
+
+	runner := NewPullRequestRunner(deps.Logger, client, repos, deps.ScrapeInterval)
+	go runner.Run(ctx)
+
+	reg.MustRegister(NewPullRequestCollector(deps.Logger, runner))
+	return nil
+}
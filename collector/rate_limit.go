@@ -0,0 +1,102 @@
+package collector
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/shurcooL/githubql"
+)
+
+// RateLimitCollector exposes the GraphQL rate limit on its own, without
+// also querying repo data. It's useful when operators only care about
+// tracking rate-limit consumption, e.g. across several exporters sharing a
+// token.
+type RateLimitCollector struct {
+	logger   log.Logger
+	client   *githubql.Client
+	authType string
+
+	limit     *prometheus.Desc
+	remaining *prometheus.Desc
+	reset     *prometheus.Desc
+}
+
+type rateLimitQuery struct {
+	RateLimit rateLimit
+}
+
+// NewRateLimitCollector returns a new RateLimitCollector. Its descriptors
+// carry a "source" const label of "rate_limit" so they don't collide with
+// Runner's identically-named rate-limit descriptors when both the
+// "organization" and "rate_limit" collectors are registered at once.
+func NewRateLimitCollector(logger log.Logger, client *githubql.Client, authType string) *RateLimitCollector {
+	constLabels := prometheus.Labels{"source": "rate_limit"}
+
+	return &RateLimitCollector{
+		logger:   logger,
+		client:   client,
+		authType: authType,
+
+		limit: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "rate_limit", "limit"),
+			"The rate limit",
+			[]string{"auth_type"}, constLabels,
+		),
+		remaining: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "rate_limit", "remaining"),
+			"The remaining requests left until hitting the rate limit",
+			[]string{"auth_type"}, constLabels,
+		),
+		reset: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "rate_limit", "reset_seconds"),
+			"Unix timestamp when the rate limit will be reset",
+			[]string{"auth_type"}, constLabels,
+		),
+	}
+}
+
+// Describe sends the super-set of all possible descriptors of metrics
+// collected by this Collector.
+func (c *RateLimitCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.limit
+	ch <- c.remaining
+	ch <- c.reset
+}
+
+// Collect is called by the Prometheus registry when collecting metrics.
+func (c *RateLimitCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var query rateLimitQuery
+	if err := c.client.Query(ctx, &query, nil); err != nil {
+		level.Warn(c.logger).Log("msg", "failed to execute rate limit query", "err", err)
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.limit, prometheus.GaugeValue, float64(query.RateLimit.Limit), c.authType)
+	ch <- prometheus.MustNewConstMetric(c.remaining, prometheus.GaugeValue, float64(query.RateLimit.Remaining), c.authType)
+	ch <- prometheus.MustNewConstMetric(c.reset, prometheus.GaugeValue, float64(query.RateLimit.ResetAt.Unix()), c.authType)
+}
+
+func init() {
+	RegisterFactory(rateLimitFactory{})
+}
+
+type rateLimitFactory struct{}
+
+func (rateLimitFactory) Name() string { return "rate_limit" }
+
+func (rateLimitFactory) New(ctx context.Context, deps Dependencies, reg prometheus.Registerer) error {
+	httpClient, err := deps.NewHTTPClient("rate_limit")
+	if err != nil {
+		return err
+	}
+	client := NewGithubqlClient(httpClient, deps.BaseURL)
+
+	reg.MustRegister(NewRateLimitCollector(deps.Logger, client, deps.AuthType))
+	return nil
+}
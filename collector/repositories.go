@@ -0,0 +1,188 @@
+package collector
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/shurcooL/githubql"
+)
+
+// RepositoriesRunner periodically queries a fixed list of "owner/name" repos
+// individually via GraphQL and keeps the results in an in-memory cache. It's
+// the repo source for users who want to track specific repos across orgs
+// they don't own, or for personal accounts that the organization(login:)
+// query rejects.
+type RepositoriesRunner struct {
+	logger   log.Logger
+	client   *githubql.Client
+	repos    []string
+	interval time.Duration
+
+	mu    sync.RWMutex
+	cache map[string]*RepoMetrics
+}
+
+type (
+	repositoryQuery struct {
+		Repository struct {
+			Name      githubql.String
+			DiskUsage githubql.Int
+			CreatedAt githubql.DateTime
+			PushedAt  githubql.DateTime
+
+			Stargazers struct {
+				TotalCount githubql.Int
+			}
+			Watchers struct {
+				TotalCount githubql.Int
+			}
+			Forks struct {
+				TotalCount githubql.Int
+			}
+			IssuesOpen struct {
+				TotalCount githubql.Int
+			} `graphql:"issuesOpen: issues(states: OPEN)"`
+			IssuesClosed struct {
+				TotalCount githubql.Int
+			} `graphql:"issuesClosed: issues(states: CLOSED)"`
+			PullRequestsOpen struct {
+				TotalCount githubql.Int
+			} `graphql:"PullRequestsOpen: pullRequests(states: OPEN)"`
+			PullRequestsClosed struct {
+				TotalCount githubql.Int
+			} `graphql:"PullRequestsClosed: pullRequests(states: CLOSED)"`
+			PullRequestsMerged struct {
+				TotalCount githubql.Int
+			} `graphql:"PullRequestsMerged: pullRequests(states: MERGED)"`
+		} `graphql:"repository(owner: $owner, name: $name)"`
+		RateLimit rateLimit
+	}
+)
+
+// NewRepositoriesRunner returns a new RepositoriesRunner. repos are
+// "owner/name" pairs, e.g. "grafana/grafana".
+func NewRepositoriesRunner(logger log.Logger, client *githubql.Client, repos []string, interval time.Duration) *RepositoriesRunner {
+	return &RepositoriesRunner{
+		logger:   logger,
+		client:   client,
+		repos:    repos,
+		interval: interval,
+		cache:    make(map[string]*RepoMetrics),
+	}
+}
+
+// Run queries every configured repo on r.interval until ctx is canceled.
+func (r *RepositoriesRunner) Run(ctx context.Context) {
+	r.scrape(ctx)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.scrape(ctx)
+		}
+	}
+}
+
+func (r *RepositoriesRunner) scrape(ctx context.Context) {
+	for _, repo := range r.repos {
+		owner, name, ok := splitOwnerName(repo)
+		if !ok {
+			level.Warn(r.logger).Log("msg", "skipping malformed repo, want owner/name", "repo", repo)
+			continue
+		}
+
+		metrics, err := r.scrapeRepository(ctx, owner, name)
+		if err != nil {
+			level.Warn(r.logger).Log("msg", "failed to scrape repository", "owner", owner, "name", name, "err", err)
+			continue
+		}
+
+		r.mu.Lock()
+		r.cache[repo] = metrics
+		r.mu.Unlock()
+	}
+}
+
+func (r *RepositoriesRunner) scrapeRepository(ctx context.Context, owner, name string) (*RepoMetrics, error) {
+	queryCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	variables := map[string]interface{}{
+		"owner": githubql.String(owner),
+		"name":  githubql.String(name),
+	}
+
+	var query repositoryQuery
+	if err := r.client.Query(queryCtx, &query, variables); err != nil {
+		return nil, err
+	}
+
+	repo := query.Repository
+	return &RepoMetrics{
+		Owner:              owner,
+		Name:               string(repo.Name),
+		CreatedAt:          repo.CreatedAt.Time,
+		PushedAt:           repo.PushedAt.Time,
+		DiskUsage:          int64(repo.DiskUsage),
+		Stargazers:         int64(repo.Stargazers.TotalCount),
+		Watchers:           int64(repo.Watchers.TotalCount),
+		Forks:              int64(repo.Forks.TotalCount),
+		IssuesOpen:         int64(repo.IssuesOpen.TotalCount),
+		IssuesClosed:       int64(repo.IssuesClosed.TotalCount),
+		PullRequestsOpen:   int64(repo.PullRequestsOpen.TotalCount),
+		PullRequestsClosed: int64(repo.PullRequestsClosed.TotalCount),
+		PullRequestsMerged: int64(repo.PullRequestsMerged.TotalCount),
+	}, nil
+}
+
+// Snapshot returns a copy of all repo metrics currently in the cache.
+func (r *RepositoriesRunner) Snapshot() []*RepoMetrics {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	metrics := make([]*RepoMetrics, 0, len(r.cache))
+	for _, m := range r.cache {
+		metrics = append(metrics, m)
+	}
+	return metrics
+}
+
+func splitOwnerName(repo string) (owner, name string, ok bool) {
+	parts := strings.SplitN(repo, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+func init() {
+	RegisterFactory(repositoriesFactory{})
+}
+
+type repositoriesFactory struct{}
+
+func (repositoriesFactory) Name() string { return "repositories" }
+
+func (repositoriesFactory) New(ctx context.Context, deps Dependencies, reg prometheus.Registerer) error {
+	httpClient, err := deps.NewHTTPClient("repositories")
+	if err != nil {
+		return err
+	}
+	client := NewGithubqlClient(httpClient, deps.BaseURL)
+
+	runner := NewRepositoriesRunner(deps.Logger, client, deps.Repos, deps.ScrapeInterval)
+	go runner.Run(ctx)
+
+	reg.MustRegister(NewOrganizationCollector(deps.Logger, runner, "repositories"))
+	return nil
+}
@@ -0,0 +1,315 @@
+package collector
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/shurcooL/githubql"
+)
+
+// RepoMetrics holds the metrics of a single repository as collected by the
+// Runner. It is the unit stored in the Runner's cache.
+type RepoMetrics struct {
+	Owner     string
+	Name      string
+	CreatedAt time.Time
+	PushedAt  time.Time
+	DiskUsage int64
+
+	Stargazers int64
+	Watchers   int64
+	Forks      int64
+
+	IssuesOpen   int64
+	IssuesClosed int64
+
+	PullRequestsOpen   int64
+	PullRequestsClosed int64
+	PullRequestsMerged int64
+}
+
+// Runner periodically walks the configured organizations via paginated
+// GraphQL queries and keeps the results in an in-memory cache, so that a
+// Prometheus scrape never has to wait on GitHub and never misses repos past
+// the first page.
+type Runner struct {
+	logger             log.Logger
+	client             *githubql.Client
+	organizations      []string
+	interval           time.Duration
+	rateLimitThreshold int
+	authType           string
+
+	mu    sync.RWMutex
+	cache map[string]*RepoMetrics
+
+	scrapeDuration    *prometheus.Desc
+	scrapeErrors      *prometheus.Desc
+	scrapeLastSuccess *prometheus.Desc
+
+	rateLimit          *prometheus.Desc
+	rateLimitRemaining *prometheus.Desc
+	rateLimitReset     *prometheus.Desc
+
+	durationMu    sync.RWMutex
+	errorsTotal   float64
+	lastSuccess   time.Time
+	lastDuration  time.Duration
+	lastRateLimit rateLimit
+}
+
+type (
+	organizationPageQuery struct {
+		Organization struct {
+			Login        githubql.String
+			Repositories struct {
+				Nodes []struct {
+					Name      githubql.String
+					DiskUsage githubql.Int
+					CreatedAt githubql.DateTime
+					PushedAt  githubql.DateTime
+
+					Stargazers struct {
+						TotalCount githubql.Int
+					}
+					Watchers struct {
+						TotalCount githubql.Int
+					}
+					Forks struct {
+						TotalCount githubql.Int
+					}
+					IssuesOpen struct {
+						TotalCount githubql.Int
+					} `graphql:"issuesOpen: issues(states: OPEN)"`
+					IssuesClosed struct {
+						TotalCount githubql.Int
+					} `graphql:"issuesClosed: issues(states: CLOSED)"`
+					PullRequestsOpen struct {
+						TotalCount githubql.Int
+					} `graphql:"PullRequestsOpen: pullRequests(states: OPEN)"`
+					PullRequestsClosed struct {
+						TotalCount githubql.Int
+					} `graphql:"PullRequestsClosed: pullRequests(states: CLOSED)"`
+					PullRequestsMerged struct {
+						TotalCount githubql.Int
+					} `graphql:"PullRequestsMerged: pullRequests(states: MERGED)"`
+				}
+				PageInfo struct {
+					EndCursor   githubql.String
+					HasNextPage githubql.Boolean
+				}
+			} `graphql:"repositories(first: 100, after: $cursor)"`
+		} `graphql:"organization(login: $organization)"`
+		RateLimit rateLimit
+	}
+)
+
+// NewRunner returns a new Runner. interval controls how often each
+// organization is re-walked, and rateLimitThreshold is the number of
+// remaining GraphQL points below which the Runner sleeps until the rate
+// limit resets. Its rate-limit descriptors carry a "source" const label of
+// "organization" so they don't collide with RateLimitCollector's when both
+// are registered at once.
+func NewRunner(logger log.Logger, client *githubql.Client, organizations []string, interval time.Duration, rateLimitThreshold int, authType string) *Runner {
+	return &Runner{
+		logger:             logger,
+		client:             client,
+		organizations:      organizations,
+		interval:           interval,
+		rateLimitThreshold: rateLimitThreshold,
+		authType:           authType,
+		cache:              make(map[string]*RepoMetrics),
+
+		scrapeDuration: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "scrape", "duration_seconds"),
+			"Duration of the last background scrape of all organizations",
+			nil, nil,
+		),
+		scrapeErrors: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "scrape", "errors_total"),
+			"Total number of background scrapes that failed",
+			nil, nil,
+		),
+		scrapeLastSuccess: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "scrape", "last_success_timestamp"),
+			"Unix timestamp of the last successful background scrape",
+			nil, nil,
+		),
+
+		rateLimit: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "rate_limit", "limit"),
+			"The rate limit",
+			[]string{"auth_type"}, prometheus.Labels{"source": "organization"},
+		),
+		rateLimitRemaining: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "rate_limit", "remaining"),
+			"The remaining requests left until hitting the rate limit",
+			[]string{"auth_type"}, prometheus.Labels{"source": "organization"},
+		),
+		rateLimitReset: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "rate_limit", "reset_seconds"),
+			"Unix timestamp when the rate limit will be reset",
+			[]string{"auth_type"}, prometheus.Labels{"source": "organization"},
+		),
+	}
+}
+
+// Run walks the organizations on Runner.interval until ctx is canceled. It
+// runs an initial scrape immediately so the cache isn't empty while the
+// exporter waits for the first tick.
+func (r *Runner) Run(ctx context.Context) {
+	r.scrape(ctx)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.scrape(ctx)
+		}
+	}
+}
+
+func (r *Runner) scrape(ctx context.Context) {
+	start := time.Now()
+
+	var scrapeErr error
+	for _, organization := range r.organizations {
+		if err := r.scrapeOrganization(ctx, organization); err != nil {
+			level.Warn(r.logger).Log("msg", "failed to scrape organization", "organization", organization, "err", err)
+			scrapeErr = err
+		}
+	}
+
+	r.durationMu.Lock()
+	r.lastDuration = time.Since(start)
+	if scrapeErr != nil {
+		r.errorsTotal++
+	} else {
+		r.lastSuccess = time.Now()
+	}
+	r.durationMu.Unlock()
+}
+
+func (r *Runner) scrapeOrganization(ctx context.Context, organization string) error {
+	var cursor githubql.String
+	hasCursor := false
+
+	for {
+		variables := map[string]interface{}{
+			"organization": githubql.String(organization),
+			"cursor":       (*githubql.String)(nil),
+		}
+		if hasCursor {
+			variables["cursor"] = &cursor
+		}
+
+		queryCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		var query organizationPageQuery
+		err := r.client.Query(queryCtx, &query, variables)
+		cancel()
+		if err != nil {
+			return err
+		}
+
+		r.mu.Lock()
+		for _, repo := range query.Organization.Repositories.Nodes {
+			key := organization + "/" + string(repo.Name)
+			r.cache[key] = &RepoMetrics{
+				Owner:              organization,
+				Name:               string(repo.Name),
+				CreatedAt:          repo.CreatedAt.Time,
+				PushedAt:           repo.PushedAt.Time,
+				DiskUsage:          int64(repo.DiskUsage),
+				Stargazers:         int64(repo.Stargazers.TotalCount),
+				Watchers:           int64(repo.Watchers.TotalCount),
+				Forks:              int64(repo.Forks.TotalCount),
+				IssuesOpen:         int64(repo.IssuesOpen.TotalCount),
+				IssuesClosed:       int64(repo.IssuesClosed.TotalCount),
+				PullRequestsOpen:   int64(repo.PullRequestsOpen.TotalCount),
+				PullRequestsClosed: int64(repo.PullRequestsClosed.TotalCount),
+				PullRequestsMerged: int64(repo.PullRequestsMerged.TotalCount),
+			}
+		}
+		r.mu.Unlock()
+
+		r.durationMu.Lock()
+		r.lastRateLimit = query.RateLimit
+		r.durationMu.Unlock()
+
+		r.honorRateLimit(query.RateLimit)
+
+		if !bool(query.Organization.Repositories.PageInfo.HasNextPage) {
+			return nil
+		}
+		cursor = query.Organization.Repositories.PageInfo.EndCursor
+		hasCursor = true
+	}
+}
+
+// honorRateLimit sleeps until the rate limit resets when the remaining
+// quota has fallen below the configured threshold, so the background loop
+// never burns through the whole GraphQL budget in one pass.
+func (r *Runner) honorRateLimit(rl rateLimit) {
+	if int(rl.Remaining) >= r.rateLimitThreshold {
+		return
+	}
+
+	sleep := time.Until(rl.ResetAt.Time)
+	if sleep <= 0 {
+		return
+	}
+
+	level.Warn(r.logger).Log(
+		"msg", "rate limit threshold reached, sleeping until reset",
+		"remaining", rl.Remaining,
+		"resetAt", rl.ResetAt.Time,
+	)
+	time.Sleep(sleep)
+}
+
+// Snapshot returns a copy of all repo metrics currently in the cache.
+func (r *Runner) Snapshot() []*RepoMetrics {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	metrics := make([]*RepoMetrics, 0, len(r.cache))
+	for _, m := range r.cache {
+		metrics = append(metrics, m)
+	}
+	return metrics
+}
+
+// Describe sends the super-set of all possible descriptors of metrics
+// collected by this Collector.
+func (r *Runner) Describe(ch chan<- *prometheus.Desc) {
+	ch <- r.scrapeDuration
+	ch <- r.scrapeErrors
+	ch <- r.scrapeLastSuccess
+	ch <- r.rateLimit
+	ch <- r.rateLimitRemaining
+	ch <- r.rateLimitReset
+}
+
+// Collect is called by the Prometheus registry when collecting metrics.
+func (r *Runner) Collect(ch chan<- prometheus.Metric) {
+	r.durationMu.RLock()
+	defer r.durationMu.RUnlock()
+
+	ch <- prometheus.MustNewConstMetric(r.scrapeDuration, prometheus.GaugeValue, r.lastDuration.Seconds())
+	ch <- prometheus.MustNewConstMetric(r.scrapeErrors, prometheus.CounterValue, r.errorsTotal)
+	if !r.lastSuccess.IsZero() {
+		ch <- prometheus.MustNewConstMetric(r.scrapeLastSuccess, prometheus.GaugeValue, float64(r.lastSuccess.Unix()))
+	}
+
+	ch <- prometheus.MustNewConstMetric(r.rateLimit, prometheus.GaugeValue, float64(r.lastRateLimit.Limit), r.authType)
+	ch <- prometheus.MustNewConstMetric(r.rateLimitRemaining, prometheus.GaugeValue, float64(r.lastRateLimit.Remaining), r.authType)
+	ch <- prometheus.MustNewConstMetric(r.rateLimitReset, prometheus.GaugeValue, float64(r.lastRateLimit.ResetAt.Unix()), r.authType)
+}
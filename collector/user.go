@@ -0,0 +1,191 @@
+package collector
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/shurcooL/githubql"
+)
+
+// UserRunner periodically walks the repos owned by the configured personal
+// accounts, the same way Runner does for organizations. It exists because
+// GitHub's organization(login:) query rejects personal accounts.
+type UserRunner struct {
+	logger   log.Logger
+	client   *githubql.Client
+	users    []string
+	interval time.Duration
+
+	mu    sync.RWMutex
+	cache map[string]*RepoMetrics
+}
+
+type (
+	userPageQuery struct {
+		User struct {
+			Login        githubql.String
+			Repositories struct {
+				Nodes []struct {
+					Name      githubql.String
+					DiskUsage githubql.Int
+					CreatedAt githubql.DateTime
+					PushedAt  githubql.DateTime
+
+					Stargazers struct {
+						TotalCount githubql.Int
+					}
+					Watchers struct {
+						TotalCount githubql.Int
+					}
+					Forks struct {
+						TotalCount githubql.Int
+					}
+					IssuesOpen struct {
+						TotalCount githubql.Int
+					} `graphql:"issuesOpen: issues(states: OPEN)"`
+					IssuesClosed struct {
+						TotalCount githubql.Int
+					} `graphql:"issuesClosed: issues(states: CLOSED)"`
+					PullRequestsOpen struct {
+						TotalCount githubql.Int
+					} `graphql:"PullRequestsOpen: pullRequests(states: OPEN)"`
+					PullRequestsClosed struct {
+						TotalCount githubql.Int
+					} `graphql:"PullRequestsClosed: pullRequests(states: CLOSED)"`
+					PullRequestsMerged struct {
+						TotalCount githubql.Int
+					} `graphql:"PullRequestsMerged: pullRequests(states: MERGED)"`
+				}
+				PageInfo struct {
+					EndCursor   githubql.String
+					HasNextPage githubql.Boolean
+				}
+			} `graphql:"repositories(first: 100, after: $cursor)"`
+		} `graphql:"user(login: $user)"`
+		RateLimit rateLimit
+	}
+)
+
+// NewUserRunner returns a new UserRunner.
+func NewUserRunner(logger log.Logger, client *githubql.Client, users []string, interval time.Duration) *UserRunner {
+	return &UserRunner{
+		logger:   logger,
+		client:   client,
+		users:    users,
+		interval: interval,
+		cache:    make(map[string]*RepoMetrics),
+	}
+}
+
+// Run walks the users on r.interval until ctx is canceled.
+func (r *UserRunner) Run(ctx context.Context) {
+	r.scrape(ctx)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.scrape(ctx)
+		}
+	}
+}
+
+func (r *UserRunner) scrape(ctx context.Context) {
+	for _, user := range r.users {
+		if err := r.scrapeUser(ctx, user); err != nil {
+			level.Warn(r.logger).Log("msg", "failed to scrape user", "user", user, "err", err)
+		}
+	}
+}
+
+func (r *UserRunner) scrapeUser(ctx context.Context, user string) error {
+	var cursor githubql.String
+	hasCursor := false
+
+	for {
+		variables := map[string]interface{}{
+			"user":   githubql.String(user),
+			"cursor": (*githubql.String)(nil),
+		}
+		if hasCursor {
+			variables["cursor"] = &cursor
+		}
+
+		queryCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		var query userPageQuery
+		err := r.client.Query(queryCtx, &query, variables)
+		cancel()
+		if err != nil {
+			return err
+		}
+
+		r.mu.Lock()
+		for _, repo := range query.User.Repositories.Nodes {
+			key := user + "/" + string(repo.Name)
+			r.cache[key] = &RepoMetrics{
+				Owner:              user,
+				Name:               string(repo.Name),
+				CreatedAt:          repo.CreatedAt.Time,
+				PushedAt:           repo.PushedAt.Time,
+				DiskUsage:          int64(repo.DiskUsage),
+				Stargazers:         int64(repo.Stargazers.TotalCount),
+				Watchers:           int64(repo.Watchers.TotalCount),
+				Forks:              int64(repo.Forks.TotalCount),
+				IssuesOpen:         int64(repo.IssuesOpen.TotalCount),
+				IssuesClosed:       int64(repo.IssuesClosed.TotalCount),
+				PullRequestsOpen:   int64(repo.PullRequestsOpen.TotalCount),
+				PullRequestsClosed: int64(repo.PullRequestsClosed.TotalCount),
+				PullRequestsMerged: int64(repo.PullRequestsMerged.TotalCount),
+			}
+		}
+		r.mu.Unlock()
+
+		if !bool(query.User.Repositories.PageInfo.HasNextPage) {
+			return nil
+		}
+		cursor = query.User.Repositories.PageInfo.EndCursor
+		hasCursor = true
+	}
+}
+
+// Snapshot returns a copy of all repo metrics currently in the cache.
+func (r *UserRunner) Snapshot() []*RepoMetrics {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	metrics := make([]*RepoMetrics, 0, len(r.cache))
+	for _, m := range r.cache {
+		metrics = append(metrics, m)
+	}
+	return metrics
+}
+
+func init() {
+	RegisterFactory(userFactory{})
+}
+
+type userFactory struct{}
+
+func (userFactory) Name() string { return "user" }
+
+func (userFactory) New(ctx context.Context, deps Dependencies, reg prometheus.Registerer) error {
+	httpClient, err := deps.NewHTTPClient("user")
+	if err != nil {
+		return err
+	}
+	client := NewGithubqlClient(httpClient, deps.BaseURL)
+
+	runner := NewUserRunner(deps.Logger, client, deps.Users, deps.ScrapeInterval)
+	go runner.Run(ctx)
+
+	reg.MustRegister(NewOrganizationCollector(deps.Logger, runner, "user"))
+	return nil
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"net/http"
 	"os"
 	"runtime"
@@ -9,16 +10,18 @@ import (
 	"time"
 
 	arg "github.com/alexflint/go-arg"
+	"github.com/bradleyfalzon/ghinstallation"
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
 	"github.com/joho/godotenv"
 	"github.com/metalmatze/githubql_exporter/collector"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
-	"github.com/shurcooL/githubql"
 	"golang.org/x/oauth2"
 )
 
+const namespace = "github"
+
 var (
 	// Version of githubql_exporter.
 	Version string
@@ -34,11 +37,27 @@ var (
 
 // Config gets its content from env and passes it on to different packages
 type Config struct {
-	Debug       bool   `arg:"env:DEBUG"`
-	GitHubToken string `arg:"env:GITHUB_TOKEN"`
-	Orgs        string `arg:"env:ORGS"`
-	WebAddr     string `arg:"env:WEB_ADDR"`
-	WebPath     string `arg:"env:WEB_PATH"`
+	Debug          bool   `arg:"env:DEBUG"`
+	GitHubToken    string `arg:"env:GITHUB_TOKEN"`
+	GitHubBaseURL  string `arg:"env:GITHUB_BASE_URL"`
+	GitHubInsecure bool   `arg:"env:GITHUB_INSECURE"`
+	Orgs           string `arg:"env:ORGS"`
+
+	GitHubAppID             int64  `arg:"env:GITHUB_APP_ID"`
+	GitHubAppInstallationID int64  `arg:"env:GITHUB_APP_INSTALLATION_ID"`
+	GitHubAppPrivateKey     string `arg:"env:GITHUB_APP_PRIVATE_KEY"`
+	GitHubAppPrivateKeyFile string `arg:"env:GITHUB_APP_PRIVATE_KEY_FILE"`
+
+	ScrapeInterval     time.Duration `arg:"env:SCRAPE_INTERVAL"`
+	RateLimitThreshold int           `arg:"env:RATE_LIMIT_THRESHOLD"`
+
+	Repos string `arg:"env:REPOS"`
+	Users string `arg:"env:USERS"`
+
+	Collectors string `arg:"env:COLLECTORS"`
+
+	WebAddr string `arg:"env:WEB_ADDR"`
+	WebPath string `arg:"env:WEB_PATH"`
 }
 
 // Token returns a token or an error.
@@ -48,17 +67,127 @@ func (c Config) Token() oauth2.TokenSource {
 	)
 }
 
+// Transport returns the http.RoundTripper to use for all GitHub requests,
+// skipping TLS verification when GitHubInsecure is set.
+func (c Config) Transport() http.RoundTripper {
+	if !c.GitHubInsecure {
+		return http.DefaultTransport
+	}
+	return &http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	}
+}
+
+// instrumentedRoundTripper wraps a base http.RoundTripper to count failed
+// requests, labeled by the collector that issued them.
+type instrumentedRoundTripper struct {
+	next     http.RoundTripper
+	failures prometheus.Counter
+}
+
+func (t instrumentedRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		t.failures.Inc()
+	}
+	return resp, err
+}
+
+// instrument wraps base so every request issued through it observes
+// github_request_duration_seconds for the given collector, and each request
+// that fails with a transport error increments github_request_failures_total.
+func instrument(base http.RoundTripper, collectorName string, duration *prometheus.HistogramVec, failures *prometheus.CounterVec) http.RoundTripper {
+	counted := instrumentedRoundTripper{next: base, failures: failures.WithLabelValues(collectorName)}
+	return promhttp.InstrumentRoundTripperDuration(duration.MustCurryWith(prometheus.Labels{"collector": collectorName}), counted)
+}
+
+// HasAppAuth reports whether the config carries GitHub App credentials,
+// in which case they take precedence over GitHubToken.
+func (c Config) HasAppAuth() bool {
+	return c.GitHubAppID != 0
+}
+
+// AuthType labels metrics with the kind of credentials in use.
+func (c Config) AuthType() string {
+	if c.HasAppAuth() {
+		return "app"
+	}
+	return "pat"
+}
+
+// AppTransport wraps base in a ghinstallation.Transport that signs requests
+// as the configured GitHub App installation, refreshing the installation
+// token automatically before it expires. On GitHub Enterprise Server the
+// REST v3 API base it needs is derived from the configured GraphQL
+// endpoint, since the two can't share GitHubBaseURL's value verbatim.
+func (c Config) AppTransport(base http.RoundTripper) (http.RoundTripper, error) {
+	var (
+		transport *ghinstallation.Transport
+		err       error
+	)
+
+	if c.GitHubAppPrivateKeyFile != "" {
+		transport, err = ghinstallation.NewFromFile(base, c.GitHubAppID, c.GitHubAppInstallationID, c.GitHubAppPrivateKeyFile)
+	} else {
+		transport, err = ghinstallation.New(base, c.GitHubAppID, c.GitHubAppInstallationID, []byte(c.GitHubAppPrivateKey))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if c.GitHubBaseURL != "" {
+		transport.BaseURL = collector.RESTBaseURL(c.GitHubBaseURL)
+	}
+
+	return transport, nil
+}
+
+// HTTPClient builds an authenticated *http.Client on top of transport,
+// using GitHub App auth when configured and falling back to the static PAT
+// otherwise. It returns the auth type used, for labeling metrics.
+func (c Config) HTTPClient(transport http.RoundTripper) (*http.Client, string, error) {
+	if c.HasAppAuth() {
+		appTransport, err := c.AppTransport(transport)
+		if err != nil {
+			return nil, "", err
+		}
+		return &http.Client{Transport: appTransport}, "app", nil
+	}
+
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, &http.Client{Transport: transport})
+	return oauth2.NewClient(ctx, c.Token()), "pat", nil
+}
+
+// splitCSV splits a comma-separated flag value into its parts, dropping
+// empty entries so an unset flag yields nil rather than [""].
+func splitCSV(s string) []string {
+	if s == "" {
+		return nil
+	}
+
+	var parts []string
+	for _, part := range strings.Split(s, ",") {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
 func main() {
 	_ = godotenv.Load()
 
 	c := Config{
-		WebPath: "/metrics",
-		WebAddr: ":9276",
+		WebPath:            "/metrics",
+		WebAddr:            ":9276",
+		ScrapeInterval:     5 * time.Minute,
+		RateLimitThreshold: 100,
+		Collectors:         "organization",
 	}
 	arg.MustParse(&c)
 
-	if c.GitHubToken == "" {
-		panic("GITHUB_TOKEN is required")
+	if c.GitHubToken == "" && !c.HasAppAuth() {
+		panic("GITHUB_TOKEN or GITHUB_APP_ID/GITHUB_APP_INSTALLATION_ID/GITHUB_APP_PRIVATE_KEY(_FILE) are required")
 	}
 
 	filterOption := level.AllowInfo()
@@ -81,12 +210,45 @@ func main() {
 		"goVersion", GoVersion,
 	)
 
-	httpClient := oauth2.NewClient(context.Background(), c.Token())
-	client := githubql.NewClient(httpClient)
+	reqDuration := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "request_duration_seconds",
+		Help:      "Duration of GitHub API requests",
+	}, []string{"collector"})
+	reqFailures := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "request_failures_total",
+		Help:      "Total number of failed GitHub API requests",
+	}, []string{"collector"})
+	prometheus.MustRegister(reqDuration, reqFailures)
 
-	organizations := strings.Split(c.Orgs, ",")
+	deps := collector.Dependencies{
+		Logger:             logger,
+		ScrapeInterval:     c.ScrapeInterval,
+		RateLimitThreshold: c.RateLimitThreshold,
+		AuthType:           c.AuthType(),
+		BaseURL:            c.GitHubBaseURL,
+		Organizations:      splitCSV(c.Orgs),
+		Repos:              splitCSV(c.Repos),
+		Users:              splitCSV(c.Users),
+		NewHTTPClient: func(collectorName string) (*http.Client, error) {
+			httpClient, _, err := c.HTTPClient(instrument(c.Transport(), collectorName, reqDuration, reqFailures))
+			return httpClient, err
+		},
+	}
 
-	prometheus.MustRegister(collector.NewOrganizationCollector(logger, client, organizations))
+	ctx := context.Background()
+	for _, name := range splitCSV(c.Collectors) {
+		factory, ok := collector.LookupFactory(name)
+		if !ok {
+			level.Error(logger).Log("msg", "unknown collector", "collector", name)
+			os.Exit(1)
+		}
+		if err := factory.New(ctx, deps, prometheus.DefaultRegisterer); err != nil {
+			level.Error(logger).Log("msg", "failed to set up collector", "collector", name, "err", err)
+			os.Exit(1)
+		}
+	}
 
 	http.Handle(c.WebPath, promhttp.Handler())
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {